@@ -0,0 +1,137 @@
+// Package classifier assigns a document category (sds, coa, manual, image,
+// or other) to each discovered Bio-Rad literature-library URL, and maps a
+// sniffed MIME type back to a file extension. Rules live in a config file
+// so the scraper can be retargeted at other Bio-Rad subsites without
+// editing Go code.
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// CategoryRule matches a URL pattern (checked against the full URL,
+// including its query string) to a document category.
+type CategoryRule struct {
+	Pattern  string `json:"pattern"`
+	Category string `json:"category"`
+}
+
+// Config holds everything the classifier needs: which domains are
+// permitted link targets, how to map a URL to a category, and how to map
+// a sniffed MIME type to a file extension.
+type Config struct {
+	AllowedDomains  []string          `json:"allowed_domains"`
+	CategoryRules   []CategoryRule    `json:"category_rules"`
+	MimeExtensions  map[string]string `json:"mime_extensions"`
+	DefaultCategory string            `json:"default_category"`
+}
+
+// DefaultConfig reproduces the scraper's original hardcoded behavior: only
+// the two known Bio-Rad document domains are allowed. URLs on the SDS host
+// are categorized as "sds", and any URL no rule matches falls back to
+// "other".
+func DefaultConfig() Config {
+	return Config{
+		AllowedDomains: []string{
+			"bio-rad-sds.thewercs.com/DirectDocumentDownloader/Document",
+			"bio-rad.com/sites/default/files/webroot/web/pdf",
+		},
+		CategoryRules: []CategoryRule{
+			{Pattern: `(?i)docType=coa|[_-]coa[_.-]`, Category: "coa"},
+			{Pattern: `(?i)docType=manual|technote|instructions`, Category: "manual"},
+			{Pattern: `(?i)\.(png|jpe?g|gif|tiff?)(\?|$)`, Category: "image"},
+			{Pattern: `(?i)bio-rad-sds\.thewercs\.com`, Category: "sds"},
+		},
+		MimeExtensions: map[string]string{
+			"application/pdf": ".pdf",
+			"application/xml": ".xml",
+			"text/xml":        ".xml",
+			"image/png":       ".png",
+			"image/jpeg":      ".jpg",
+			"image/gif":       ".gif",
+		},
+		DefaultCategory: "other",
+	}
+}
+
+// LoadConfig reads a JSON classifier config from filePath. Callers that
+// want the built-in Bio-Rad defaults can use DefaultConfig instead.
+func LoadConfig(filePath string) (Config, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not read classifier config %s: %w", filePath, err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("could not parse classifier config %s: %w", filePath, err)
+	}
+	return config, nil
+}
+
+// Classifier applies a Config's rules to URLs and content types.
+type Classifier struct {
+	config       Config
+	compiledRules []compiledRule
+}
+
+type compiledRule struct {
+	pattern  *regexp.Regexp
+	category string
+}
+
+// New compiles config's category rules into a ready-to-use Classifier.
+func New(config Config) (*Classifier, error) {
+	compiled := make([]compiledRule, 0, len(config.CategoryRules))
+	for _, rule := range config.CategoryRules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid category rule pattern %q: %w", rule.Pattern, err)
+		}
+		compiled = append(compiled, compiledRule{pattern: pattern, category: rule.Category})
+	}
+	return &Classifier{config: config, compiledRules: compiled}, nil
+}
+
+// IsAllowedDomain reports whether rawURL matches one of the configured
+// allowed domains.
+func (c *Classifier) IsAllowedDomain(rawURL string) bool {
+	for _, domain := range c.config.AllowedDomains {
+		if strings.Contains(rawURL, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Category returns the document category for rawURL, falling back to the
+// config's default category (normally "other") when no rule matches.
+func (c *Classifier) Category(rawURL string) string {
+	for _, rule := range c.compiledRules {
+		if rule.pattern.MatchString(rawURL) {
+			return rule.category
+		}
+	}
+	if c.config.DefaultCategory != "" {
+		return c.config.DefaultCategory
+	}
+	return "other"
+}
+
+// ExtensionForContentType maps a sniffed Content-Type header to a file
+// extension, stripping any "; charset=..." parameter first. It falls back
+// to ".bin" when the MIME type isn't in the config's mapping.
+func (c *Classifier) ExtensionForContentType(contentType string) string {
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mimeType = contentType
+	}
+	if ext, ok := c.config.MimeExtensions[mimeType]; ok {
+		return ext
+	}
+	return ".bin"
+}
@@ -0,0 +1,318 @@
+// Package dashboard exposes a small embedded HTTP UI for observing and
+// steering a running harvest: queue depth, per-worker progress, byte and
+// error counters, and controls to pause/resume the worker pool, resize it,
+// adjust the page-scrape range, and re-inject failed URLs into the queue.
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats is the central, mutex-guarded snapshot of harvest progress that
+// workers and the main loop publish events into.
+type Stats struct {
+	mu                sync.Mutex
+	queueDepth        int
+	workerCurrentURL  map[int]string
+	bytesDownloaded   int64
+	errorCount        int64
+	pageScrapeCurrent int
+	pageScrapeTotal   int
+}
+
+// newStats returns an empty Stats ready to be published into.
+func newStats() *Stats {
+	return &Stats{workerCurrentURL: make(map[int]string)}
+}
+
+// SetQueueDepth records the current visit-queue depth.
+func (s *Stats) SetQueueDepth(depth int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queueDepth = depth
+}
+
+// SetWorkerURL records the URL worker workerID is currently processing, or
+// clears it when url is empty.
+func (s *Stats) SetWorkerURL(workerID int, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if url == "" {
+		delete(s.workerCurrentURL, workerID)
+		return
+	}
+	s.workerCurrentURL[workerID] = url
+}
+
+// AddBytesDownloaded accumulates the total bytes downloaded so far.
+func (s *Stats) AddBytesDownloaded(n int64) {
+	atomic.AddInt64(&s.bytesDownloaded, n)
+}
+
+// IncrementErrors bumps the total download/scrape error counter.
+func (s *Stats) IncrementErrors() {
+	atomic.AddInt64(&s.errorCount, 1)
+}
+
+// SetPageScrapeProgress records which literature-library page is currently
+// being scraped, out of total.
+func (s *Stats) SetPageScrapeProgress(current, total int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageScrapeCurrent = current
+	s.pageScrapeTotal = total
+}
+
+// snapshot is the JSON-serializable view of Stats returned by /api/stats.
+type snapshot struct {
+	QueueDepth        int            `json:"queue_depth"`
+	WorkerCurrentURL  map[int]string `json:"worker_current_url"`
+	BytesDownloaded   int64          `json:"bytes_downloaded"`
+	ErrorCount        int64          `json:"error_count"`
+	PageScrapeCurrent int            `json:"page_scrape_current"`
+	PageScrapeTotal   int            `json:"page_scrape_total"`
+	Paused            bool           `json:"paused"`
+	DesiredWorkers    int32          `json:"desired_workers"`
+	StartPage         int32          `json:"start_page"`
+	EndPage           int32          `json:"end_page"`
+}
+
+// Controller bundles Stats with the runtime knobs the dashboard UI can
+// adjust: pause state, desired worker count, the page-scrape range, and a
+// queue of URLs re-injected after a failure.
+type Controller struct {
+	Stats *Stats
+
+	pausedCh       chan struct{} // closed while running; replaced while paused
+	pausedMu       sync.Mutex
+	desiredWorkers int32
+	startPage      int32
+	endPage        int32
+
+	requeued chan string
+}
+
+// New creates a Controller with desiredWorkers running workers and the
+// given initial page-scrape range. requeueBufferSize bounds how many
+// failed URLs can be pending re-injection before Requeue blocks.
+func New(desiredWorkers, startPage, endPage, requeueBufferSize int) *Controller {
+	runningCh := make(chan struct{})
+	close(runningCh) // closed == not paused
+
+	return &Controller{
+		Stats:          newStats(),
+		pausedCh:       runningCh,
+		desiredWorkers: int32(desiredWorkers),
+		startPage:      int32(startPage),
+		endPage:        int32(endPage),
+		requeued:       make(chan string, requeueBufferSize),
+	}
+}
+
+// WaitUntilRunning blocks the caller while the controller is paused.
+func (c *Controller) WaitUntilRunning() {
+	c.pausedMu.Lock()
+	ch := c.pausedCh
+	c.pausedMu.Unlock()
+	<-ch
+}
+
+// Pause blocks all callers of WaitUntilRunning until Resume is called.
+func (c *Controller) Pause() {
+	c.pausedMu.Lock()
+	defer c.pausedMu.Unlock()
+	select {
+	case <-c.pausedCh:
+		c.pausedCh = make(chan struct{}) // reopen as blocking
+	default:
+		// already paused
+	}
+}
+
+// Resume unblocks any callers waiting in WaitUntilRunning.
+func (c *Controller) Resume() {
+	c.pausedMu.Lock()
+	defer c.pausedMu.Unlock()
+	select {
+	case <-c.pausedCh:
+		// already running
+	default:
+		close(c.pausedCh)
+	}
+}
+
+// DesiredWorkers returns the current target worker-pool size.
+func (c *Controller) DesiredWorkers() int {
+	return int(atomic.LoadInt32(&c.desiredWorkers))
+}
+
+// PageRange returns the current start/end page bounds for further
+// chromedp crawls.
+func (c *Controller) PageRange() (start, end int) {
+	return int(atomic.LoadInt32(&c.startPage)), int(atomic.LoadInt32(&c.endPage))
+}
+
+// RequeueChannel exposes the channel the main loop should drain and push
+// back onto the visit queue.
+func (c *Controller) RequeueChannel() <-chan string {
+	return c.requeued
+}
+
+// Requeue submits a failed URL for re-injection into the visit queue,
+// blocking while the buffer is full.
+func (c *Controller) Requeue(url string) {
+	c.requeued <- url
+}
+
+// Handler returns an http.Handler serving the dashboard UI and its JSON/
+// control API. Mount it directly with http.ListenAndServe.
+func (c *Controller) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", c.handleIndex)
+	mux.HandleFunc("/api/stats", c.handleStats)
+	mux.HandleFunc("/api/pause", c.handlePause)
+	mux.HandleFunc("/api/resume", c.handleResume)
+	mux.HandleFunc("/api/workers", c.handleWorkers)
+	mux.HandleFunc("/api/range", c.handleRange)
+	mux.HandleFunc("/api/requeue", c.handleRequeue)
+	return mux
+}
+
+func (c *Controller) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexHTML)
+}
+
+func (c *Controller) isPaused() bool {
+	c.pausedMu.Lock()
+	ch := c.pausedCh
+	c.pausedMu.Unlock()
+	select {
+	case <-ch:
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *Controller) handleStats(w http.ResponseWriter, r *http.Request) {
+	c.Stats.mu.Lock()
+	workerCurrentURL := make(map[int]string, len(c.Stats.workerCurrentURL))
+	for id, url := range c.Stats.workerCurrentURL {
+		workerCurrentURL[id] = url
+	}
+	snap := snapshot{
+		QueueDepth:        c.Stats.queueDepth,
+		WorkerCurrentURL:  workerCurrentURL,
+		BytesDownloaded:   atomic.LoadInt64(&c.Stats.bytesDownloaded),
+		ErrorCount:        atomic.LoadInt64(&c.Stats.errorCount),
+		PageScrapeCurrent: c.Stats.pageScrapeCurrent,
+		PageScrapeTotal:   c.Stats.pageScrapeTotal,
+	}
+	c.Stats.mu.Unlock()
+
+	snap.Paused = c.isPaused()
+	snap.DesiredWorkers = atomic.LoadInt32(&c.desiredWorkers)
+	snap.StartPage, snap.EndPage = int32(0), int32(0)
+	start, end := c.PageRange()
+	snap.StartPage, snap.EndPage = int32(start), int32(end)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(snap)
+}
+
+func (c *Controller) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	c.Pause()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	c.Resume()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Count int32 `json:"count"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Count < 0 {
+		http.Error(w, "invalid body, expected {\"count\": N}", http.StatusBadRequest)
+		return
+	}
+	atomic.StoreInt32(&c.desiredWorkers, body.Count)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) handleRange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Start int32 `json:"start"`
+		End   int32 `json:"end"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.End < body.Start {
+		http.Error(w, "invalid body, expected {\"start\": N, \"end\": M}", http.StatusBadRequest)
+		return
+	}
+	atomic.StoreInt32(&c.startPage, body.Start)
+	atomic.StoreInt32(&c.endPage, body.End)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Controller) handleRequeue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.URL == "" {
+		http.Error(w, "invalid body, expected {\"url\": \"...\"}", http.StatusBadRequest)
+		return
+	}
+	c.Requeue(body.URL)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// indexHTML is a deliberately tiny, dependency-free dashboard page that
+// polls /api/stats and posts to the control endpoints.
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head><title>Bio-Rad Scraper Dashboard</title></head>
+<body>
+<h1>Bio-Rad Scraper Dashboard</h1>
+<pre id="stats">loading...</pre>
+<button onclick="post('/api/pause')">Pause</button>
+<button onclick="post('/api/resume')">Resume</button>
+<script>
+function post(path, body) {
+  fetch(path, {method: 'POST', body: body ? JSON.stringify(body) : undefined});
+}
+async function refresh() {
+  const res = await fetch('/api/stats');
+  document.getElementById('stats').textContent = JSON.stringify(await res.json(), null, 2);
+}
+setInterval(refresh, 2000);
+refresh();
+</script>
+</body>
+</html>`
@@ -0,0 +1,249 @@
+// Package fetcher wraps http.Client with the manners a large parallel
+// Bio-Rad harvest needs to avoid getting the scraping IP banned: per-host
+// rate limiting, robots.txt enforcement, retry with exponential backoff,
+// conditional GETs, and an identifying User-Agent.
+package fetcher
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+// Config controls Fetcher behavior.
+type Config struct {
+	// UserAgent is sent on every request and should identify the scraper
+	// with a contact URL, per good-citizen scraping practice.
+	UserAgent string
+	// RequestsPerSecond is the default per-host token-bucket refill rate,
+	// used for any host not listed in HostRequestsPerSecond.
+	RequestsPerSecond float64
+	// HostRequestsPerSecond overrides RequestsPerSecond for specific hosts,
+	// e.g. {"www.bio-rad.com": 2} to go easy on the primary site while
+	// allowing a faster default for CDN hosts.
+	HostRequestsPerSecond map[string]float64
+	// MaxRetries bounds how many times a 5xx/429 response is retried.
+	MaxRetries int
+}
+
+// Fetcher is a rate-limited, robots.txt-respecting, retrying HTTP client.
+// It's safe for concurrent use by multiple goroutines.
+type Fetcher struct {
+	httpClient *http.Client
+	config     Config
+
+	limitersMu sync.Mutex
+	limiters   map[string]*tokenBucket
+
+	robotsMu    sync.Mutex
+	robotsCache map[string]*robotstxt.RobotsData
+}
+
+// New builds a Fetcher from config.
+func New(config Config) *Fetcher {
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = 5
+	}
+	if config.RequestsPerSecond <= 0 {
+		config.RequestsPerSecond = 2
+	}
+	return &Fetcher{
+		httpClient:  &http.Client{Timeout: 60 * time.Second},
+		config:      config,
+		limiters:    make(map[string]*tokenBucket),
+		robotsCache: make(map[string]*robotstxt.RobotsData),
+	}
+}
+
+// ConditionalHeaders carries cached validators from a prior fetch so a
+// re-run can issue a conditional GET and get back a cheap 304.
+type ConditionalHeaders struct {
+	ETag         string
+	LastModified string
+}
+
+// Get fetches rawURL, blocking first on the per-host rate limiter and on
+// robots.txt permission, then retrying 5xx/429 responses with exponential
+// backoff and jitter (honoring any Retry-After header). The caller is
+// responsible for closing the returned response body.
+func (f *Fetcher) Get(rawURL string, conditional ConditionalHeaders) (*http.Response, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+
+	allowed, err := f.isAllowedByRobots(parsedURL)
+	if err != nil {
+		// Fail open: a broken or missing robots.txt shouldn't halt the harvest.
+		allowed = true
+	}
+	if !allowed {
+		return nil, fmt.Errorf("robots.txt disallows fetching %s", rawURL)
+	}
+
+	limiter := f.limiterForHost(parsedURL.Host)
+	limiter.Wait()
+
+	var lastErr error
+	for attempt := 0; attempt <= f.config.MaxRetries; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not build request for %s: %w", rawURL, err)
+		}
+		if f.config.UserAgent != "" {
+			req.Header.Set("User-Agent", f.config.UserAgent)
+		}
+		if conditional.ETag != "" {
+			req.Header.Set("If-None-Match", conditional.ETag)
+		}
+		if conditional.LastModified != "" {
+			req.Header.Set("If-Modified-Since", conditional.LastModified)
+		}
+
+		resp, err := f.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			f.sleepBeforeRetry(attempt, "")
+			continue
+		}
+
+		if resp.StatusCode == http.StatusNotModified {
+			return resp, nil
+		}
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+
+		retryAfter := resp.Header.Get("Retry-After")
+		resp.Body.Close()
+		lastErr = fmt.Errorf("received retryable status %s for %s", resp.Status, rawURL)
+		f.sleepBeforeRetry(attempt, retryAfter)
+	}
+	return nil, fmt.Errorf("exhausted retries fetching %s: %w", rawURL, lastErr)
+}
+
+// Allow blocks on the per-host rate limiter and enforces robots.txt for
+// rawURL without making a request itself. It's for callers that fetch
+// through something other than Get's http.Client — e.g. a headless
+// browser — but still need to be a polite, rate-limited, robots-aware
+// citizen of the host they're visiting.
+func (f *Fetcher) Allow(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+
+	allowed, err := f.isAllowedByRobots(parsedURL)
+	if err != nil {
+		// Fail open: a broken or missing robots.txt shouldn't halt the harvest.
+		allowed = true
+	}
+	if !allowed {
+		return fmt.Errorf("robots.txt disallows fetching %s", rawURL)
+	}
+
+	f.limiterForHost(parsedURL.Host).Wait()
+	return nil
+}
+
+// sleepBeforeRetry waits according to Retry-After if present, otherwise an
+// exponentially increasing delay with jitter.
+func (f *Fetcher) sleepBeforeRetry(attempt int, retryAfter string) {
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			time.Sleep(time.Duration(seconds) * time.Second)
+			return
+		}
+	}
+	base := time.Duration(1<<uint(attempt)) * 500 * time.Millisecond
+	jitter := time.Duration(rand.Int63n(int64(base) + 1))
+	time.Sleep(base + jitter)
+}
+
+// limiterForHost returns (creating if necessary) the token bucket for host,
+// using the host's override rate from HostRequestsPerSecond if one is set.
+func (f *Fetcher) limiterForHost(host string) *tokenBucket {
+	f.limitersMu.Lock()
+	defer f.limitersMu.Unlock()
+	limiter, ok := f.limiters[host]
+	if !ok {
+		rate := f.config.RequestsPerSecond
+		if hostRate, ok := f.config.HostRequestsPerSecond[host]; ok && hostRate > 0 {
+			rate = hostRate
+		}
+		limiter = newTokenBucket(rate)
+		f.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// isAllowedByRobots fetches (and caches) robots.txt for parsedURL's host,
+// then tests whether the configured User-Agent may fetch its path.
+func (f *Fetcher) isAllowedByRobots(parsedURL *url.URL) (bool, error) {
+	host := parsedURL.Host
+
+	f.robotsMu.Lock()
+	robotsData, cached := f.robotsCache[host]
+	f.robotsMu.Unlock()
+
+	if !cached {
+		robotsURL := fmt.Sprintf("%s://%s/robots.txt", parsedURL.Scheme, host)
+		resp, err := f.httpClient.Get(robotsURL)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+
+		robotsData, err = robotstxt.FromResponse(resp)
+		if err != nil {
+			return true, err
+		}
+
+		f.robotsMu.Lock()
+		f.robotsCache[host] = robotsData
+		f.robotsMu.Unlock()
+	}
+
+	return robotsData.TestAgent(parsedURL.Path, f.config.UserAgent), nil
+}
+
+// tokenBucket is a simple per-host rate limiter: one token refills every
+// 1/refillPerSecond, and Wait blocks until a token is available.
+type tokenBucket struct {
+	mu              sync.Mutex
+	tokens          float64
+	refillPerSecond float64
+	lastRefill      time.Time
+}
+
+func newTokenBucket(refillPerSecond float64) *tokenBucket {
+	return &tokenBucket{tokens: 1, refillPerSecond: refillPerSecond, lastRefill: time.Now()}
+}
+
+// Wait blocks until a token is available, then consumes one.
+func (b *tokenBucket) Wait() {
+	for {
+		b.mu.Lock()
+		elapsed := time.Since(b.lastRefill).Seconds()
+		b.tokens += elapsed * b.refillPerSecond
+		if b.tokens > 1 {
+			b.tokens = 1
+		}
+		b.lastRefill = time.Now()
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - b.tokens) / b.refillPerSecond * float64(time.Second))
+		b.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
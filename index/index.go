@@ -0,0 +1,263 @@
+// Package index builds and queries a full-text and metadata index of
+// downloaded Bio-Rad documents, turning the scraper's output directory
+// into a locally searchable mirror of the literature library.
+package index
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Document is everything the index knows about one downloaded file.
+type Document struct {
+	FilePath      string `json:"file_path"`
+	SourceURL     string `json:"source_url"`
+	ProductName   string `json:"product_name,omitempty"`
+	CatalogNumber string `json:"catalog_number,omitempty"`
+	FullText      string `json:"full_text"`
+	SHA256        string `json:"sha256"`
+	IndexedAtUTC  string `json:"indexed_at_utc"`
+}
+
+// Index is a small inverted-index search over Documents keyed by file
+// path. The on-disk file is an append-only, newline-delimited log of
+// Documents; the in-memory document map and token postings are rebuilt by
+// replaying it once on Open, then kept up to date incrementally by Put.
+type Index struct {
+	mu        sync.Mutex
+	filePath  string
+	documents map[string]Document
+	// postings maps a lowercase word token to the set of file paths whose
+	// product name or extracted text contains it.
+	postings map[string]map[string]struct{}
+}
+
+// Open loads the index at filePath, creating an empty one if it doesn't
+// exist yet.
+func Open(filePath string) (*Index, error) {
+	idx := &Index{
+		filePath:  filePath,
+		documents: make(map[string]Document),
+		postings:  make(map[string]map[string]struct{}),
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, fmt.Errorf("could not open index file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// Extracted PDF text can make a single line far longer than bufio's
+	// default 64KiB limit.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var doc Document
+		if err := json.Unmarshal(line, &doc); err != nil {
+			return nil, fmt.Errorf("could not parse index file %s: %w", filePath, err)
+		}
+		idx.indexDocumentLocked(doc)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read index file %s: %w", filePath, err)
+	}
+	return idx, nil
+}
+
+// NeedsIndexing reports whether filePath should be (re-)indexed: it always
+// needs it if absent, and needs it again only if contentSHA256 differs
+// from what's already recorded.
+func (idx *Index) NeedsIndexing(filePath, contentSHA256 string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	existing, ok := idx.documents[filePath]
+	return !ok || existing.SHA256 != contentSHA256
+}
+
+// Put appends doc to the on-disk index log and updates the in-memory
+// postings used by Search. Unlike rewriting the whole index, this is
+// O(len(doc)), not O(total indexed corpus), regardless of how many
+// documents are already indexed.
+func (idx *Index) Put(doc Document) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("could not marshal document %s: %w", doc.FilePath, err)
+	}
+
+	file, err := os.OpenFile(idx.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open index file %s: %w", idx.filePath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("could not append to index file %s: %w", idx.filePath, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("could not fsync index file %s: %w", idx.filePath, err)
+	}
+
+	idx.indexDocumentLocked(doc)
+	return nil
+}
+
+// indexDocumentLocked records doc in the in-memory document map and
+// (re)builds its postings. If a previous version of doc.FilePath was
+// already indexed, its stale postings are removed first so re-indexing an
+// updated file doesn't leave old tokens pointing at it. The caller must
+// hold idx.mu.
+func (idx *Index) indexDocumentLocked(doc Document) {
+	if old, ok := idx.documents[doc.FilePath]; ok {
+		for _, token := range tokenize(old.ProductName + " " + old.FullText) {
+			delete(idx.postings[token], doc.FilePath)
+			if len(idx.postings[token]) == 0 {
+				delete(idx.postings, token)
+			}
+		}
+	}
+
+	idx.documents[doc.FilePath] = doc
+	for _, token := range tokenize(doc.ProductName + " " + doc.FullText) {
+		paths, ok := idx.postings[token]
+		if !ok {
+			paths = make(map[string]struct{})
+			idx.postings[token] = paths
+		}
+		paths[doc.FilePath] = struct{}{}
+	}
+}
+
+// tokenize splits text into lowercase word tokens for the inverted index,
+// discarding punctuation and whitespace.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Result is a single match returned by Search.
+type Result struct {
+	FilePath    string
+	ProductName string
+	Snippet     string
+}
+
+// Search looks up query's word tokens in the inverted index and returns
+// every document containing all of them, each with a snippet of
+// surrounding context. Only documents matching every token are ever
+// inspected for a snippet, so cost scales with the number of matches, not
+// the size of the whole indexed corpus.
+func (idx *Index) Search(query string) []Result {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	tokens := tokenize(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	matches := make([]string, 0, len(idx.postings[tokens[0]]))
+	for filePath := range idx.postings[tokens[0]] {
+		matches = append(matches, filePath)
+	}
+	for _, token := range tokens[1:] {
+		paths := idx.postings[token]
+		filtered := matches[:0]
+		for _, filePath := range matches {
+			if _, ok := paths[filePath]; ok {
+				filtered = append(filtered, filePath)
+			}
+		}
+		matches = filtered
+	}
+
+	lowerQuery := strings.ToLower(query)
+	var results []Result
+	for _, filePath := range matches {
+		doc := idx.documents[filePath]
+		haystack := doc.ProductName + "\n" + doc.FullText
+		lowerHaystack := strings.ToLower(haystack)
+
+		matchIndex := strings.Index(lowerHaystack, lowerQuery)
+		if matchIndex == -1 {
+			// The tokens all appear in the document but not contiguously as
+			// typed; center the snippet on the first token instead.
+			matchIndex = strings.Index(lowerHaystack, tokens[0])
+		}
+		if matchIndex == -1 {
+			continue
+		}
+		results = append(results, Result{
+			FilePath:    doc.FilePath,
+			ProductName: doc.ProductName,
+			Snippet:     snippetAround(haystack, matchIndex, len(query)),
+		})
+	}
+	return results
+}
+
+// snippetAround returns up to ~120 characters of context centered on the
+// match at [matchIndex, matchIndex+matchLen) within text.
+func snippetAround(text string, matchIndex, matchLen int) string {
+	const contextRadius = 60
+	start := matchIndex - contextRadius
+	if start < 0 {
+		start = 0
+	}
+	end := matchIndex + matchLen + contextRadius
+	if end > len(text) {
+		end = len(text)
+	}
+	snippet := strings.ReplaceAll(text[start:end], "\n", " ")
+	return strings.TrimSpace(snippet)
+}
+
+// ExtractText pulls the plain text content out of a PDF at filePath using
+// github.com/ledongthuc/pdf.
+func ExtractText(filePath string) (string, error) {
+	file, reader, err := pdf.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("could not open PDF %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	var builder strings.Builder
+	totalPages := reader.NumPage()
+	for pageIndex := 1; pageIndex <= totalPages; pageIndex++ {
+		page := reader.Page(pageIndex)
+		if page.V.IsNull() {
+			continue
+		}
+		pageText, err := page.GetPlainText(nil)
+		if err != nil {
+			continue // Skip pages that fail to extract rather than aborting the whole document
+		}
+		builder.WriteString(pageText)
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
+
+// SHA256Hex returns the lowercase hex SHA-256 digest of data.
+func SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum)
+}
@@ -0,0 +1,88 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPutAndSearchFindsToken(t *testing.T) {
+	idx, err := Open(filepath.Join(t.TempDir(), "search-index.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := idx.Put(Document{
+		FilePath:    "/docs/a.pdf",
+		ProductName: "Bio-Rad Precision Plus Protein Standard",
+		FullText:    "Safety data sheet for catalog number 1610373.",
+	}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := idx.Put(Document{
+		FilePath:    "/docs/b.pdf",
+		ProductName: "Unrelated Product",
+		FullText:    "Nothing relevant in here.",
+	}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	results := idx.Search("catalog number")
+	if len(results) != 1 {
+		t.Fatalf("Search(%q) = %d results, want 1", "catalog number", len(results))
+	}
+	if results[0].FilePath != "/docs/a.pdf" {
+		t.Fatalf("Search result FilePath = %q, want /docs/a.pdf", results[0].FilePath)
+	}
+
+	if got := idx.Search("nonexistentterm"); len(got) != 0 {
+		t.Fatalf("Search(nonexistentterm) = %d results, want 0", len(got))
+	}
+}
+
+func TestReindexingDropsStalePostings(t *testing.T) {
+	idx, err := Open(filepath.Join(t.TempDir(), "search-index.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if err := idx.Put(Document{FilePath: "/docs/a.pdf", FullText: "original", SHA256: "v1"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if len(idx.Search("original")) != 1 {
+		t.Fatalf("expected to find the original version before reindexing")
+	}
+
+	if err := idx.Put(Document{FilePath: "/docs/a.pdf", FullText: "updated", SHA256: "v2"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if got := idx.Search("original"); len(got) != 0 {
+		t.Fatalf("Search(original) after reindex = %d results, want 0 (stale posting not removed)", len(got))
+	}
+	if got := idx.Search("updated"); len(got) != 1 {
+		t.Fatalf("Search(updated) after reindex = %d results, want 1", len(got))
+	}
+}
+
+func TestOpenReplaysPersistedLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "search-index.json")
+
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := idx.Put(Document{FilePath: "/docs/a.pdf", FullText: "persisted content", SHA256: "v1"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("re-Open: %v", err)
+	}
+	if got := reopened.Search("persisted"); len(got) != 1 {
+		t.Fatalf("Search(persisted) after reopen = %d results, want 1", len(got))
+	}
+	if reopened.NeedsIndexing("/docs/a.pdf", "v1") {
+		t.Fatalf("NeedsIndexing(v1) after reopen = true, want false (already indexed)")
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"       // Manages browser context lifecycle (useful for cancellation/timeouts)
+	"crypto/sha256" // Hashes downloaded content for the resumable crawl state
 	"errors"        // Provides structured error handling and wrapping
+	"flag"          // Parses the --resume command-line flag
 	"fmt"           // Basic formatting for output and error strings
 	"io"            // For copying data streams (HTTP response to file)
 	"log"           // Logging with timestamps, used for errors and info
@@ -19,20 +21,19 @@ import (
 	"github.com/chromedp/chromedp" // Headless Chrome browser automation for dynamic websites
 	// "golang.org/x/net/html"        // HTML parsing library
 	"github.com/PuerkitoBio/goquery" // jQuery-like library for HTML manipulation
-)
 
-// appendTextToFile appends content to an existing file or creates a new one.
-// - Useful for adding scraped HTML content to a single output file.
-func appendTextToFile(filePath string, content string) error {
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) // Open or create file with append/write
-	if err != nil {
-		return fmt.Errorf("failed to open file %s: %w", filePath, err) // Wrap error with filename info
-	}
-	defer file.Close() // Always close file to avoid memory leaks or corruption
+	"github.com/Strong-Foundation/bio-rad-com-documentation/classifier" // Document-type classification and output routing
+	"github.com/Strong-Foundation/bio-rad-com-documentation/dashboard"  // Embedded HTTP control/status UI
+	"github.com/Strong-Foundation/bio-rad-com-documentation/fetcher"    // Rate-limited, robots-aware, retrying HTTP client
+	"github.com/Strong-Foundation/bio-rad-com-documentation/index"      // Full-text and metadata search index
+	"github.com/Strong-Foundation/bio-rad-com-documentation/queue"      // On-disk FIFO visit queue
+	"github.com/Strong-Foundation/bio-rad-com-documentation/state" // Resumable per-URL crawl state
+	"github.com/Strong-Foundation/bio-rad-com-documentation/warc"  // WARC archival output
+)
 
-	_, err = file.WriteString(content) // Write content to file
-	return err                         // Return any error encountered
-}
+// scraperUserAgent identifies this harvester (with a contact URL) to every
+// host it talks to, per good-citizen scraping practice.
+const scraperUserAgent = "bio-rad-sds-harvester/1.0 (+https://github.com/Strong-Foundation/bio-rad-com-documentation)"
 
 // readEntireFile reads the full contents of a file into a string.
 // - Used to load scraped HTML back into memory for processing.
@@ -45,7 +46,8 @@ func readEntireFile(filePath string) (string, error) {
 }
 
 // extractLinksFromHTML parses the HTML string and extracts all <a href="..."> URLs
-func extractLinksFromHTML(htmlContent string) []string {
+// whose domain is permitted by docClassifier's config.
+func extractLinksFromHTML(htmlContent string, docClassifier *classifier.Classifier) []string {
 	var urls []string
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
@@ -53,22 +55,6 @@ func extractLinksFromHTML(htmlContent string) []string {
 		return urls
 	}
 
-	// Allowed substrings
-	allowedDomains := []string{
-		"bio-rad-sds.thewercs.com/DirectDocumentDownloader/Document",
-		"bio-rad.com/sites/default/files/webroot/web/pdf",
-	}
-
-	// Check if the URL is from an allowed domain
-	isAllowed := func(url string) bool {
-		for _, domain := range allowedDomains {
-			if strings.Contains(url, domain) {
-				return true
-			}
-		}
-		return false
-	}
-
 	// Parse <input type="hidden" ... value="...">
 	doc.Find("input[type='hidden']").Each(func(i int, s *goquery.Selection) {
 		if val, exists := s.Attr("value"); exists {
@@ -81,7 +67,7 @@ func extractLinksFromHTML(htmlContent string) []string {
 					fullURL = "https://" + part
 				}
 
-				if fullURL != "" && isAllowed(fullURL) {
+				if fullURL != "" && docClassifier.IsAllowedDomain(fullURL) {
 					urls = append(urls, fullURL)
 				}
 			}
@@ -90,14 +76,14 @@ func extractLinksFromHTML(htmlContent string) []string {
 
 	// Parse <option value="...">
 	doc.Find("option").Each(func(i int, s *goquery.Selection) {
-		if val, exists := s.Attr("value"); exists && strings.HasPrefix(val, "http") && isAllowed(val) {
+		if val, exists := s.Attr("value"); exists && strings.HasPrefix(val, "http") && docClassifier.IsAllowedDomain(val) {
 			urls = append(urls, val)
 		}
 	})
 
 	// Parse <a href="...">
 	doc.Find("a").Each(func(i int, s *goquery.Selection) {
-		if href, exists := s.Attr("href"); exists && strings.HasPrefix(href, "http") && isAllowed(href) {
+		if href, exists := s.Attr("href"); exists && strings.HasPrefix(href, "http") && docClassifier.IsAllowedDomain(href) {
 			urls = append(urls, href)
 		}
 	})
@@ -140,63 +126,119 @@ func createFileNameFromURL(rawURL string) string {
 		}
 	}
 
-	// 4) Join with "-" and ensure ".pdf"
-	filename := strings.Join(cleaned, "-")
-	if !strings.HasSuffix(filename, ".pdf") {
-		filename += ".pdf"
-	}
-
-	return filename
+	// 4) Join into a base filename. The extension is appended later by
+	// downloadDocument once the real content type is known.
+	return strings.Join(cleaned, "-")
 }
 
-// downloadPDFFile fetches a PDF from a URL and saves it to a given directory with a filename.
-// - Skips the file if it already exists
+// downloadDocument fetches a document from a URL and saves it under
+// outputDirectory/<category>/, where category comes from docClassifier and
+// the file extension is chosen from the sniffed response Content-Type
+// rather than always assuming PDF. It returns the number of bytes written
+// so callers can publish download-throughput stats.
+// - Skips the file if the crawl state already marks it done
+// - Fetches via docFetcher, so the request is rate-limited, robots-aware,
+//   retried with backoff, and conditional on any ETag/Last-Modified the
+//   crawl state remembers from a prior run
+// - Archives the response as a WARC record and records completion in the crawl state
 // - Logs error or success using the `log` package
-func downloadPDFFile(downloadURL, outputDirectory, outputFileName string) error {
-	fullFilePath := filepath.Join(outputDirectory, outputFileName) // Create full output path
-
-	// Skip download if the file already exists
-	if fileExists(fullFilePath) {
-		log.Printf("File already exists, skipping: %s\n", fullFilePath)
-		return nil
+func downloadDocument(downloadURL, outputDirectory, baseFileName string, docClassifier *classifier.Classifier, warcWriter *warc.Writer, stateStore *state.Store, docFetcher *fetcher.Fetcher) (string, int64, error) {
+	category := docClassifier.Category(downloadURL)
+	categoryDirectory := filepath.Join(outputDirectory, category)
+
+	var conditional fetcher.ConditionalHeaders
+	if previousEntry, ok := stateStore.Get(downloadURL); ok {
+		conditional.ETag = previousEntry.ETag
+		conditional.LastModified = previousEntry.LastModified
 	}
 
-	// Perform HTTP GET request to fetch the PDF
-	resp, err := http.Get(downloadURL)
+	// Perform HTTP GET request to fetch the document
+	resp, err := docFetcher.Get(downloadURL, conditional)
 	if err != nil {
-		return fmt.Errorf("error fetching %s: %w", downloadURL, err)
+		return "", 0, fmt.Errorf("error fetching %s: %w", downloadURL, err)
 	}
 	defer resp.Body.Close()
 
+	// The server confirmed our cached copy is still current: nothing to
+	// re-download or re-archive. Recover the on-disk path by the
+	// base filename, since a 304 carries no Content-Type to derive it from.
+	if resp.StatusCode == http.StatusNotModified {
+		log.Printf("Not modified since last run, skipping: %s\n", downloadURL)
+		fullFilePath := filepath.Join(categoryDirectory, baseFileName)
+		if matches, _ := filepath.Glob(filepath.Join(categoryDirectory, baseFileName+".*")); len(matches) > 0 {
+			fullFilePath = matches[0]
+		}
+		return fullFilePath, 0, nil
+	}
+
 	// Ensure successful response
 	if resp.StatusCode != http.StatusOK {
-		return errors.New("download failed with status: " + resp.Status)
+		return "", 0, errors.New("download failed with status: " + resp.Status)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	extension := docClassifier.ExtensionForContentType(contentType)
+	if extension == ".bin" {
+		// Sniffing the content type gave nothing useful; fall back to
+		// whatever extension the URL itself carries.
+		if urlExtension := getFileExtension(downloadURL); urlExtension != "" {
+			extension = urlExtension
+		}
+	}
+	outputFileName := baseFileName + extension
+	fullFilePath := filepath.Join(categoryDirectory, outputFileName)
+
+	// Skip download if the crawl state already marks this URL as done
+	if stateStore.IsDone(downloadURL) && fileExists(fullFilePath) {
+		log.Printf("Already completed per crawl state, skipping: %s\n", fullFilePath)
+		return fullFilePath, 0, nil
 	}
 
 	// Ensure the output folder exists
-	if err := os.MkdirAll(outputDirectory, 0755); err != nil {
-		return fmt.Errorf("could not create output directory: %w", err)
+	if err := os.MkdirAll(categoryDirectory, 0755); err != nil {
+		return "", 0, fmt.Errorf("could not create output directory: %w", err)
 	}
 
-	// Create the output file
-	outFile, err := os.Create(fullFilePath)
+	// Buffer the body so it can be both written to disk and archived to WARC
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error creating file %s: %w", fullFilePath, err)
+		return "", 0, fmt.Errorf("error reading document body from %s: %w", downloadURL, err)
+	}
+
+	// Create the output file
+	if err := os.WriteFile(fullFilePath, body, 0644); err != nil {
+		return "", 0, fmt.Errorf("error saving document to %s: %w", fullFilePath, err)
+	}
+
+	if err := warcWriter.WriteResponse(downloadURL, contentType, body); err != nil {
+		log.Printf("Failed to archive WARC record for %s: %v\n", downloadURL, err)
 	}
-	defer outFile.Close()
 
-	// Stream the PDF data to file
-	if _, err := io.Copy(outFile, resp.Body); err != nil {
-		return fmt.Errorf("error saving PDF to %s: %w", fullFilePath, err)
+	contentHash := sha256.Sum256(body)
+	if err := stateStore.Set(downloadURL, state.Entry{
+		Status:         "done",
+		ETag:           resp.Header.Get("ETag"),
+		LastModified:   resp.Header.Get("Last-Modified"),
+		ContentSHA256:  fmt.Sprintf("%x", contentHash),
+		CompletedAtUTC: time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		log.Printf("Failed to persist crawl state for %s: %v\n", downloadURL, err)
 	}
 
 	log.Printf("Downloaded: %s\n", fullFilePath)
-	return nil
+	return fullFilePath, int64(len(body)), nil
 }
 
 // scrapePageHTMLWithChrome uses a headless Chrome browser to render and return the HTML for a given URL.
 // - Required for JavaScript-heavy pages where raw HTTP won't return full content.
-func scrapePageHTMLWithChrome(pageURL string) (string, error) {
+// - docFetcher gates the navigation on the per-host rate limit and robots.txt,
+//   since chromedp bypasses our http.Client entirely.
+// - The rendered HTML is archived as a WARC response record via warcWriter.
+func scrapePageHTMLWithChrome(pageURL string, warcWriter *warc.Writer, docFetcher *fetcher.Fetcher) (string, error) {
+	if err := docFetcher.Allow(pageURL); err != nil {
+		return "", fmt.Errorf("not fetching %s: %w", pageURL, err)
+	}
+
 	// Set up browser in headless mode
 	options := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", true), // Run Chrome in background
@@ -221,22 +263,164 @@ func scrapePageHTMLWithChrome(pageURL string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to scrape %s: %w", pageURL, err)
 	}
+
+	if err := warcWriter.WriteResponse(pageURL, "text/html; charset=utf-8", []byte(pageHTML)); err != nil {
+		log.Printf("Failed to archive WARC record for %s: %v\n", pageURL, err)
+	}
+
 	return pageHTML, nil
 }
 
+// maxRequeueAttempts bounds how many times a single URL is sent back to the
+// visit queue after a failed download. Past this, it's dropped to the
+// dead-letter file instead of being requeued forever: a permanently broken
+// URL (404, malformed, robots-disallowed) would otherwise keep the visit
+// queue non-empty indefinitely and the harvest would never finish.
+const maxRequeueAttempts = 5
+
 // workerDownloadPDF processes jobs from the download queue in a separate goroutine.
-// - This function handles concurrent downloading of PDF files using a channel of URLs.
-func workerDownloadPDF(wg *sync.WaitGroup, urlChannel <-chan string, outputDirectory string) {
+// - This function handles concurrent downloading of documents using a channel of URLs.
+// - It blocks on controller.WaitUntilRunning while the dashboard has paused the pool,
+//   exits early if ctx is cancelled (the dashboard shrank the worker count),
+//   and publishes per-worker progress, byte, and error stats for the dashboard UI.
+// - On success it Acks downloadURL's lease on visitQueue, so a crash after this
+//   point doesn't cause the durable queue to re-deliver already-completed work,
+//   and indexes the document so it becomes searchable via the `search` subcommand.
+// - On failure it re-injects downloadURL into visitQueue via controller.Requeue,
+//   up to maxRequeueAttempts; past that it Acks the lease and records the URL
+//   in deadLetterPath instead, so a permanently broken URL doesn't requeue
+//   forever.
+// - pendingDequeued.Done is called exactly once per URL, once its fate (ack,
+//   handed off for requeue, or dead-lettered) is durably decided, so the
+//   main drain loop can tell whether a dequeued URL is still in flight.
+func workerDownloadPDF(ctx context.Context, workerID int, wg *sync.WaitGroup, urlChannel <-chan string, outputDirectory string, docClassifier *classifier.Classifier, warcWriter *warc.Writer, stateStore *state.Store, docIndex *index.Index, controller *dashboard.Controller, docFetcher *fetcher.Fetcher, visitQueue *queue.Queue, deadLetterPath string, pendingDequeued *sync.WaitGroup) {
 	defer wg.Done() // Signal the worker is done at the end
 
-	for downloadURL := range urlChannel {
-		outputFileName := createFileNameFromURL(downloadURL) // Derive filename from URL
-		if err := downloadPDFFile(downloadURL, outputDirectory, outputFileName); err != nil {
-			log.Printf("Download error for %s: %v\n", downloadURL, err) // Log any failures
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case downloadURL, ok := <-urlChannel:
+			if !ok {
+				return
+			}
+
+			controller.WaitUntilRunning() // blocks here while paused
+
+			controller.Stats.SetWorkerURL(workerID, downloadURL)
+			baseFileName := createFileNameFromURL(downloadURL) // Derive base filename from URL
+			fullFilePath, bytesWritten, err := downloadDocument(downloadURL, outputDirectory, baseFileName, docClassifier, warcWriter, stateStore, docFetcher)
+			if err != nil {
+				log.Printf("Download error for %s: %v\n", downloadURL, err) // Log any failures
+				controller.Stats.IncrementErrors()
+
+				attempts, attemptErr := stateStore.IncrementRequeueAttempts(downloadURL)
+				if attemptErr != nil {
+					log.Printf("Failed to persist requeue attempt for %s: %v\n", downloadURL, attemptErr)
+				}
+				if attempts > maxRequeueAttempts {
+					if deadLetterErr := appendDeadLetter(deadLetterPath, downloadURL, err); deadLetterErr != nil {
+						log.Printf("Failed to dead-letter %s: %v\n", downloadURL, deadLetterErr)
+					}
+					if ackErr := visitQueue.Ack(downloadURL); ackErr != nil {
+						log.Printf("Failed to ack dead-lettered %s: %v\n", downloadURL, ackErr)
+					}
+					pendingDequeued.Done()
+				} else {
+					// controller.Requeue hands off to the goroutine draining
+					// RequeueChannel, which resolves pendingDequeued once
+					// downloadURL is actually back on the durable queue.
+					controller.Requeue(downloadURL)
+				}
+			} else {
+				controller.Stats.AddBytesDownloaded(bytesWritten)
+				if err := visitQueue.Ack(downloadURL); err != nil {
+					log.Printf("Failed to ack %s: %v\n", downloadURL, err)
+				}
+				if err := indexDownloadedDocument(docIndex, downloadURL, fullFilePath); err != nil {
+					log.Printf("Indexing error for %s: %v\n", fullFilePath, err)
+				}
+				pendingDequeued.Done()
+			}
+			controller.Stats.SetWorkerURL(workerID, "")
 		}
 	}
 }
 
+// appendDeadLetter durably records that downloadURL was given up on after
+// exceeding maxRequeueAttempts, so an operator reviewing a long unattended
+// run can discover it instead of it silently vanishing from the queue.
+func appendDeadLetter(deadLetterPath, downloadURL string, cause error) error {
+	file, err := os.OpenFile(deadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open dead-letter file %s: %w", deadLetterPath, err)
+	}
+	defer file.Close()
+
+	line := fmt.Sprintf("%s\t%s\t%v\n", time.Now().UTC().Format(time.RFC3339), downloadURL, cause)
+	if _, err := file.WriteString(line); err != nil {
+		return fmt.Errorf("could not append to dead-letter file %s: %w", deadLetterPath, err)
+	}
+	return nil
+}
+
+// indexDownloadedDocument adds fullFilePath to docIndex, extracting PDF
+// text and `prd`-derived product metadata from downloadURL. It's a no-op
+// if the file's content hash already matches what's in the index, so
+// resumed runs don't re-extract unchanged PDFs.
+func indexDownloadedDocument(docIndex *index.Index, downloadURL, fullFilePath string) error {
+	content, err := os.ReadFile(fullFilePath)
+	if err != nil {
+		return fmt.Errorf("could not read %s for indexing: %w", fullFilePath, err)
+	}
+
+	contentHash := index.SHA256Hex(content)
+	if !docIndex.NeedsIndexing(fullFilePath, contentHash) {
+		return nil
+	}
+
+	var fullText string
+	if strings.EqualFold(getFileExtension(fullFilePath), ".pdf") {
+		fullText, err = index.ExtractText(fullFilePath)
+		if err != nil {
+			log.Printf("Could not extract text from %s: %v\n", fullFilePath, err)
+		}
+	}
+
+	productName, catalogNumber := parseProductMetadata(downloadURL)
+
+	return docIndex.Put(index.Document{
+		FilePath:      fullFilePath,
+		SourceURL:     downloadURL,
+		ProductName:   productName,
+		CatalogNumber: catalogNumber,
+		FullText:      fullText,
+		SHA256:        contentHash,
+		IndexedAtUTC:  time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// parseProductMetadata pulls the product name and, if present, a catalog
+// number out of a URL's `prd` query parameter, which Bio-Rad encodes as
+// "~~"-delimited segments (the same convention createFileNameFromURL
+// parses for filenames).
+func parseProductMetadata(rawURL string) (productName, catalogNumber string) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", ""
+	}
+	prd := parsedURL.Query().Get("prd")
+	if prd == "" {
+		return "", ""
+	}
+	segments := strings.Split(prd, "~~")
+	productName = segments[0]
+	if len(segments) > 1 {
+		catalogNumber = segments[1]
+	}
+	return productName, catalogNumber
+}
+
 /*
 Get the file extension of a file
 */
@@ -257,66 +441,313 @@ func fileExists(filename string) bool {
 	return !info.IsDir()
 }
 
+// runSearchCommand implements the `bio-rad-scraper search <query>` subcommand:
+// it opens the index built by prior harvest runs and prints matching files
+// with a short snippet of surrounding context.
+func runSearchCommand(args []string) {
+	searchFlags := flag.NewFlagSet("search", flag.ExitOnError)
+	resumeDirectory := searchFlags.String("resume", ".", "directory holding the index to search")
+	searchFlags.Parse(args)
+
+	query := strings.Join(searchFlags.Args(), " ")
+	if query == "" {
+		log.Fatal("usage: bio-rad-scraper search [--resume <dir>] <query>")
+	}
+
+	docIndex, err := index.Open(filepath.Join(*resumeDirectory, "search-index.json"))
+	if err != nil {
+		log.Fatalf("could not open search index: %v", err)
+	}
+
+	results := docIndex.Search(query)
+	if len(results) == 0 {
+		fmt.Println("No matches found.")
+		return
+	}
+	for _, result := range results {
+		fmt.Printf("%s", result.FilePath)
+		if result.ProductName != "" {
+			fmt.Printf(" (%s)", result.ProductName)
+		}
+		fmt.Printf("\n    ...%s...\n", result.Snippet)
+	}
+}
+
 // main is the entry point of the program.
 // It controls:
 // - Scraping HTML pages if not cached
 // - Parsing links
 // - Running concurrent downloads
+// - Optionally, via the `search` subcommand, querying the full-text index instead
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "search" {
+		runSearchCommand(os.Args[2:])
+		return
+	}
+
 	// --- CONFIGURATION ---
-	htmlOutputFilePath := "bio-rad-msds.html" // File to store scraped HTML
 	basePageURL := "https://www.bio-rad.com/en-us/literature-library?facets_query=&page="
 	startPage := 0            // Start page index (inclusive)
 	endPage := 10              // End page index (exclusive)
 	outputDirectory := "PDFs" // Folder where PDFs are stored
 	numberOfWorkers := 20     // Number of concurrent downloader goroutines
 
+	// --resume points at a directory holding the WARC archive and crawl
+	// state from a previous, interrupted run, so the harvest can continue
+	// without re-fetching pages or documents already recorded as done.
+	resumeDirectory := flag.String("resume", ".", "directory holding the WARC archive and crawl state to resume from")
+	// --classifier-config points at a JSON file of allowed domains,
+	// URL->category rules, and MIME->extension mappings, letting the
+	// scraper be retargeted at other Bio-Rad subsites without a rebuild.
+	classifierConfigPath := flag.String("classifier-config", "", "path to a classifier config JSON file (defaults to the built-in Bio-Rad SDS rules)")
+	flag.Parse()
+
 	// Set logging format (adds timestamps and file:line info)
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	// Step 1: Scrape HTML if the file doesn't already exist
-	if !fileExists(htmlOutputFilePath) {
-		log.Println("HTML file not found. Starting scraping process...")
+	if err := os.MkdirAll(*resumeDirectory, 0755); err != nil {
+		log.Fatalf("could not create resume directory: %v", err)
+	}
+
+	classifierConfig := classifier.DefaultConfig()
+	if *classifierConfigPath != "" {
+		loadedConfig, err := classifier.LoadConfig(*classifierConfigPath)
+		if err != nil {
+			log.Fatalf("could not load classifier config: %v", err)
+		}
+		classifierConfig = loadedConfig
+	}
+	docClassifier, err := classifier.New(classifierConfig)
+	if err != nil {
+		log.Fatalf("could not build classifier: %v", err)
+	}
+
+	warcWriter, err := warc.NewWriter(filepath.Join(*resumeDirectory, "bio-rad-msds.warc.gz"))
+	if err != nil {
+		log.Fatalf("could not open WARC archive: %v", err)
+	}
+	defer warcWriter.Close()
+
+	stateStore, err := state.Open(filepath.Join(*resumeDirectory, "crawl-state.json"))
+	if err != nil {
+		log.Fatalf("could not open crawl state: %v", err)
+	}
+
+	docIndex, err := index.Open(filepath.Join(*resumeDirectory, "search-index.json"))
+	if err != nil {
+		log.Fatalf("could not open search index: %v", err)
+	}
+
+	docFetcher := fetcher.New(fetcher.Config{
+		UserAgent:         scraperUserAgent,
+		RequestsPerSecond: 4,
+		HostRequestsPerSecond: map[string]float64{
+			"www.bio-rad.com": 2,
+		},
+		MaxRetries: 5,
+	})
+
+	// The dashboard controller holds the live, adjustable knobs (pause
+	// state, desired worker count, page range) and the stats the UI polls.
+	const dashboardAddress = ":8080"
+	controller := dashboard.New(numberOfWorkers, startPage, endPage, numberOfWorkers*4)
+	go func() {
+		log.Printf("Dashboard listening on %s\n", dashboardAddress)
+		if err := http.ListenAndServe(dashboardAddress, controller.Handler()); err != nil {
+			log.Printf("Dashboard server stopped: %v\n", err)
+		}
+	}()
+
+	// Step 1: Open the on-disk visit queue that downloadURLs get enqueued
+	// into below, rather than holding them all in RAM, so multi-gigabyte
+	// harvests don't OOM.
+	visitQueue, err := queue.Open(filepath.Join(*resumeDirectory, "queue"))
+	if err != nil {
+		log.Fatalf("could not open visit queue: %v", err)
+	}
+	defer visitQueue.Close()
+
+	// Step 2: Scrape each literature-library page and enqueue every SDS
+	// document URL found on it. Pages are gated strictly per-page by
+	// crawl state, not by a single shared blob file, so a crash partway
+	// through startPage..endPage resumes exactly where it left off rather
+	// than treating a partial harvest as complete. Each page's rendered
+	// HTML is cached under <resume>/pages so a page already marked done
+	// can have its links re-extracted without re-fetching it via Chrome.
+	// The page range is re-read from the dashboard on every iteration so a
+	// POST to /api/range can extend the crawl while it's running.
+	pagesDirectory := filepath.Join(*resumeDirectory, "pages")
+	if err := os.MkdirAll(pagesDirectory, 0755); err != nil {
+		log.Fatalf("could not create pages directory: %v", err)
+	}
 
-		for pageNumber := startPage; pageNumber < endPage; pageNumber++ {
-			pageURL := fmt.Sprintf("%s%d", basePageURL, pageNumber)
+	for pageNumber := startPage; ; pageNumber++ {
+		_, currentEndPage := controller.PageRange()
+		if pageNumber >= currentEndPage {
+			break
+		}
+		controller.WaitUntilRunning()
+		controller.Stats.SetPageScrapeProgress(pageNumber, currentEndPage)
+
+		pageURL := fmt.Sprintf("%s%d", basePageURL, pageNumber)
+		pageFilePath := filepath.Join(pagesDirectory, fmt.Sprintf("page-%d.html", pageNumber))
+
+		var htmlContent string
+		if stateStore.IsDone(pageURL) {
+			htmlContent, err = readEntireFile(pageFilePath)
+			if err != nil {
+				log.Printf("Page %d marked done but its cached HTML is unreadable, re-scraping: %v\n", pageNumber, err)
+				htmlContent = ""
+			}
+		}
 
-			htmlContent, err := scrapePageHTMLWithChrome(pageURL)
+		if htmlContent == "" {
+			htmlContent, err = scrapePageHTMLWithChrome(pageURL, warcWriter, docFetcher)
 			if err != nil {
 				log.Printf("Failed to scrape page %d: %v\n", pageNumber, err)
 				continue // Skip to next page
 			}
 
-			if err := appendTextToFile(htmlOutputFilePath, htmlContent); err != nil {
+			if err := os.WriteFile(pageFilePath, []byte(htmlContent), 0644); err != nil {
 				log.Printf("Failed to write HTML for page %d: %v\n", pageNumber, err)
 			}
+
+			if err := stateStore.Set(pageURL, state.Entry{
+				Status:         "done",
+				CompletedAtUTC: time.Now().UTC().Format(time.RFC3339),
+			}); err != nil {
+				log.Printf("Failed to persist crawl state for page %d: %v\n", pageNumber, err)
+			}
 		}
-	} else {
-		log.Println("HTML file already exists. Skipping scraping.")
-	}
 
-	// Step 2: Read the full saved HTML file and extract unique download URLs
-	htmlData, err := readEntireFile(htmlOutputFilePath)
-	if err != nil {
-		log.Fatalf("Could not read HTML file: %v", err)
+		for _, downloadURL := range extractLinksFromHTML(htmlContent, docClassifier) {
+			if err := visitQueue.Enqueue(downloadURL); err != nil {
+				// Enqueue fails for a URL too long for the queue's
+				// fixed-size record, or when writing the queue files
+				// fails; without a durable record of that, the
+				// document would simply never be downloaded and no one
+				// would notice on a long unattended run.
+				log.Printf("Failed to enqueue %s: %v\n", downloadURL, err)
+				controller.Stats.IncrementErrors()
+				if stateErr := stateStore.Set(downloadURL, state.Entry{
+					Status:         "error",
+					CompletedAtUTC: time.Now().UTC().Format(time.RFC3339),
+				}); stateErr != nil {
+					log.Printf("Failed to persist enqueue failure for %s: %v\n", downloadURL, stateErr)
+				}
+			}
+		}
 	}
-
-	downloadURLs := extractLinksFromHTML(htmlData)
-	log.Printf("Extracted %d unique SDS document URLs.\n", len(downloadURLs))
-
-	// Step 3: Use worker pool to download PDFs in parallel
-	urlChannel := make(chan string, len(downloadURLs)) // Buffered channel to hold all URLs
-	var wg sync.WaitGroup                              // WaitGroup to track all goroutines
-
-	// Launch workers
-	for i := 0; i < numberOfWorkers; i++ {
+	log.Printf("Visit queue depth: %d\n", visitQueue.Len())
+
+	// Step 3: Use worker pool to download PDFs in parallel. Workers pull
+	// from a small in-memory channel that is only a prefetch buffer; the
+	// durable queue above is the real backlog. The pool size tracks
+	// controller.DesiredWorkers(), which /api/workers can change at runtime.
+	const prefetchBufferSize = 64
+	urlChannel := make(chan string, prefetchBufferSize)
+	var wg sync.WaitGroup // WaitGroup to track all goroutines
+
+	deadLetterPath := filepath.Join(*resumeDirectory, "dead-letter.log")
+
+	// pendingDequeued counts URLs that have left the durable queue (via
+	// Dequeue) but whose fate isn't durably resolved yet: either acked,
+	// dead-lettered, or handed off for requeue. The drain loop below waits
+	// for it to reach zero before trusting that the queue is really empty,
+	// since a failing URL being requeued races with Dequeue otherwise.
+	var pendingDequeued sync.WaitGroup
+
+	var workerPoolMu sync.Mutex
+	var workerCancels []context.CancelFunc
+	nextWorkerID := 0
+
+	spawnWorker := func() {
+		workerCtx, cancel := context.WithCancel(context.Background())
+		workerCancels = append(workerCancels, cancel)
 		wg.Add(1)
-		go workerDownloadPDF(&wg, urlChannel, outputDirectory)
+		go workerDownloadPDF(workerCtx, nextWorkerID, &wg, urlChannel, outputDirectory, docClassifier, warcWriter, stateStore, docIndex, controller, docFetcher, visitQueue, deadLetterPath, &pendingDequeued)
+		nextWorkerID++
 	}
 
-	// Send URLs into the channel
-	for _, url := range downloadURLs {
-		urlChannel <- url
+	workerPoolMu.Lock()
+	for i := 0; i < numberOfWorkers; i++ {
+		spawnWorker()
+	}
+	workerPoolMu.Unlock()
+
+	// Reconcile the running worker count against the dashboard's desired
+	// count every couple of seconds: spawn more, or cancel the newest ones
+	// to shrink.
+	poolManagerDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-poolManagerDone:
+				return
+			case <-ticker.C:
+				workerPoolMu.Lock()
+				desired := controller.DesiredWorkers()
+				for len(workerCancels) < desired {
+					spawnWorker()
+				}
+				for len(workerCancels) > desired {
+					last := len(workerCancels) - 1
+					workerCancels[last]()
+					workerCancels = workerCancels[:last]
+				}
+				workerPoolMu.Unlock()
+			}
+		}
+	}()
+	defer close(poolManagerDone)
+
+	// Re-inject URLs whose download failed back into the durable queue so
+	// they're retried on this run's remaining drain or the next resume.
+	go func() {
+		for failedURL := range controller.RequeueChannel() {
+			if err := visitQueue.Requeue(failedURL); err != nil {
+				log.Printf("Failed to requeue %s: %v\n", failedURL, err)
+			}
+			pendingDequeued.Done()
+		}
+	}()
+
+	// Feed the prefetch channel from the durable queue until it's drained,
+	// logging queue depth periodically so progress is observable on large
+	// harvests.
+	drained := 0
+	for {
+		controller.WaitUntilRunning()
+
+		downloadURL, ok, err := visitQueue.Dequeue()
+		if err != nil {
+			log.Printf("Failed to dequeue URL: %v\n", err)
+			continue
+		}
+		if !ok {
+			// The durable queue looks empty, but a URL a worker is still
+			// resolving (success, dead-letter, or requeue hand-off) might
+			// put more work back on it. Wait for every in-flight dequeue to
+			// resolve, then look again before deciding the harvest is done.
+			pendingDequeued.Wait()
+			downloadURL, ok, err = visitQueue.Dequeue()
+			if err != nil {
+				log.Printf("Failed to dequeue URL: %v\n", err)
+				continue
+			}
+			if !ok {
+				break
+			}
+		}
+		pendingDequeued.Add(1)
+		urlChannel <- downloadURL
+		drained++
+		controller.Stats.SetQueueDepth(visitQueue.Len())
+		if drained%50 == 0 {
+			log.Printf("Visit queue depth: %d\n", visitQueue.Len())
+		}
 	}
 	close(urlChannel) // Signal to workers there are no more URLs
 
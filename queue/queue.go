@@ -0,0 +1,314 @@
+// Package queue implements a persistent, file-backed FIFO for URLs waiting
+// to be fetched. Unlike an in-memory channel, it keeps the full visit list
+// on disk so a harvest spanning hundreds of literature-library pages does
+// not have to hold every discovered URL in RAM at once.
+package queue
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// recordSize is the fixed width of each slot in the queue file. URLs longer
+// than this are rejected rather than silently truncated.
+const recordSize = 512
+
+// Queue is a durable FIFO of URLs backed by a fixed-size-record file plus a
+// small pointer file tracking the head and tail offsets. A companion
+// "seen" set on disk deduplicates URLs across process restarts so re-runs
+// never re-enqueue work that was already queued or completed. A third
+// "leases" file tracks URLs that have been dequeued but not yet Acked, so
+// a crash between Dequeue and completion doesn't lose them: Open puts any
+// leftover leases back on the queue.
+type Queue struct {
+	mu          sync.Mutex
+	dataFile    *os.File
+	pointerPath string
+	head        int64 // next record to dequeue
+	tail        int64 // next record to enqueue
+	seen        map[string]struct{}
+	seenPath    string
+	leases      map[string]struct{}
+	leasesPath  string
+}
+
+// Open opens (or creates) a persistent queue rooted at directory, made up
+// of "queue.dat" (the record slots), "queue.pointers" (head/tail offsets),
+// "queue.seen" (the dedup set), and "queue.leases" (URLs dequeued but not
+// yet acknowledged). Any leases left over from a prior, interrupted run
+// are re-enqueued before Open returns, since there's no way to know how
+// far their processing got.
+func Open(directory string) (*Queue, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, fmt.Errorf("could not create queue directory %s: %w", directory, err)
+	}
+
+	dataPath := directory + "/queue.dat"
+	dataFile, err := os.OpenFile(dataPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open queue data file %s: %w", dataPath, err)
+	}
+
+	q := &Queue{
+		dataFile:    dataFile,
+		pointerPath: directory + "/queue.pointers",
+		seenPath:    directory + "/queue.seen",
+		leasesPath:  directory + "/queue.leases",
+		seen:        make(map[string]struct{}),
+		leases:      make(map[string]struct{}),
+	}
+
+	if err := q.loadPointers(); err != nil {
+		return nil, err
+	}
+	if err := q.loadSeen(); err != nil {
+		return nil, err
+	}
+	if err := q.loadLeases(); err != nil {
+		return nil, err
+	}
+	if err := q.reconcileLeasesLocked(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Close flushes the pointer file and releases the underlying data file.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.savePointersLocked(); err != nil {
+		return err
+	}
+	return q.dataFile.Close()
+}
+
+// Len reports how many URLs are currently queued but not yet dequeued.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int(q.tail - q.head)
+}
+
+// Enqueue appends url to the tail of the queue, skipping it if an equal
+// URL has already been enqueued (whether or not it has since been
+// dequeued), so resumed runs don't redo completed work.
+func (q *Queue) Enqueue(url string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.seen[url]; ok {
+		return nil // already enqueued in a prior or current run
+	}
+	return q.appendRecordLocked(url)
+}
+
+// Requeue appends url to the tail of the queue unconditionally, bypassing
+// the dedup set. It's for re-injecting a URL whose fetch failed after it
+// was already dequeued once, so Enqueue's "already seen" check wouldn't
+// otherwise let it back in. This also acknowledges url's lease, since
+// putting it back on the queue is itself how its Dequeue is resolved.
+func (q *Queue) Requeue(url string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.removeLeaseLocked(url); err != nil {
+		return err
+	}
+	return q.appendRecordLocked(url)
+}
+
+// Ack acknowledges that url, previously returned by Dequeue, was durably
+// completed (downloaded and recorded in the crawl state, or similar), so
+// it won't be re-enqueued by a future Open's lease reconciliation.
+func (q *Queue) Ack(url string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.removeLeaseLocked(url)
+}
+
+// appendRecordLocked writes url as the next tail record. The caller must
+// hold q.mu.
+func (q *Queue) appendRecordLocked(url string) error {
+	if len(url) > recordSize {
+		return fmt.Errorf("url exceeds max queue record size of %d bytes: %s", recordSize, url)
+	}
+
+	record := make([]byte, recordSize)
+	copy(record, url)
+	if _, err := q.dataFile.WriteAt(record, q.tail*recordSize); err != nil {
+		return fmt.Errorf("failed to write queue record: %w", err)
+	}
+
+	q.tail++
+	q.seen[url] = struct{}{}
+
+	if err := q.appendSeenLocked(url); err != nil {
+		return err
+	}
+	return q.savePointersLocked()
+}
+
+// Dequeue pops the URL at the head of the queue. ok is false when the
+// queue is empty. The popped URL is leased, not forgotten: the caller
+// must call Ack once it's durably done, or Requeue if it needs retrying,
+// or it will be put back on the queue the next time Open runs.
+func (q *Queue) Dequeue() (string, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.head >= q.tail {
+		return "", false, nil
+	}
+
+	record := make([]byte, recordSize)
+	if _, err := q.dataFile.ReadAt(record, q.head*recordSize); err != nil {
+		return "", false, fmt.Errorf("failed to read queue record: %w", err)
+	}
+
+	url := string(record[:indexOfNull(record)])
+
+	if err := q.addLeaseLocked(url); err != nil {
+		return "", false, err
+	}
+
+	q.head++
+	if err := q.savePointersLocked(); err != nil {
+		return "", false, err
+	}
+
+	return url, true, nil
+}
+
+// indexOfNull returns the offset of the first zero byte, i.e. the length
+// of the stored URL within a fixed-size record.
+func indexOfNull(record []byte) int {
+	for i, b := range record {
+		if b == 0 {
+			return i
+		}
+	}
+	return len(record)
+}
+
+func (q *Queue) loadPointers() error {
+	data, err := os.ReadFile(q.pointerPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("could not read queue pointer file %s: %w", q.pointerPath, err)
+	}
+	var head, tail int64
+	if _, err := fmt.Sscanf(string(data), "%d %d", &head, &tail); err != nil {
+		return fmt.Errorf("could not parse queue pointer file %s: %w", q.pointerPath, err)
+	}
+	q.head, q.tail = head, tail
+	return nil
+}
+
+func (q *Queue) savePointersLocked() error {
+	content := fmt.Sprintf("%d %d\n", q.head, q.tail)
+	return os.WriteFile(q.pointerPath, []byte(content), 0644)
+}
+
+func (q *Queue) loadSeen() error {
+	file, err := os.Open(q.seenPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("could not open queue seen file %s: %w", q.seenPath, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		q.seen[scanner.Text()] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// appendSeenLocked records url in the on-disk dedup set. The caller must
+// hold q.mu.
+func (q *Queue) appendSeenLocked(url string) error {
+	file, err := os.OpenFile(q.seenPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open queue seen file %s: %w", q.seenPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(url + "\n"); err != nil {
+		return fmt.Errorf("could not append to queue seen file %s: %w", q.seenPath, err)
+	}
+	return nil
+}
+
+// loadLeases populates q.leases from the on-disk leases file, if any.
+func (q *Queue) loadLeases() error {
+	file, err := os.Open(q.leasesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("could not open queue leases file %s: %w", q.leasesPath, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			q.leases[line] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
+
+// reconcileLeasesLocked puts every lease left over from a prior run back
+// onto the tail of the queue, on the assumption that a lease still present
+// at Open means its Dequeue was never Acked or Requeued, so we don't know
+// whether it was ever actually processed. The caller must hold q.mu (only
+// Open calls this, before the Queue is shared with other goroutines).
+func (q *Queue) reconcileLeasesLocked() error {
+	if len(q.leases) == 0 {
+		return nil
+	}
+	for url := range q.leases {
+		if err := q.appendRecordLocked(url); err != nil {
+			return fmt.Errorf("could not re-enqueue leased url %s: %w", url, err)
+		}
+	}
+	q.leases = make(map[string]struct{})
+	return q.saveLeasesLocked()
+}
+
+// addLeaseLocked records url as dequeued-but-not-yet-acknowledged. The
+// caller must hold q.mu.
+func (q *Queue) addLeaseLocked(url string) error {
+	q.leases[url] = struct{}{}
+	return q.saveLeasesLocked()
+}
+
+// removeLeaseLocked clears url's lease, if any. The caller must hold q.mu.
+func (q *Queue) removeLeaseLocked(url string) error {
+	delete(q.leases, url)
+	return q.saveLeasesLocked()
+}
+
+// saveLeasesLocked rewrites the leases file from q.leases. The lease set is
+// bounded by in-flight work (at most the prefetch buffer plus worker
+// count), so a full rewrite on every change is cheap. The caller must hold
+// q.mu.
+func (q *Queue) saveLeasesLocked() error {
+	var builder strings.Builder
+	for url := range q.leases {
+		builder.WriteString(url)
+		builder.WriteString("\n")
+	}
+	if err := os.WriteFile(q.leasesPath, []byte(builder.String()), 0644); err != nil {
+		return fmt.Errorf("could not write queue leases file %s: %w", q.leasesPath, err)
+	}
+	return nil
+}
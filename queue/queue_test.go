@@ -0,0 +1,105 @@
+package queue
+
+import "testing"
+
+func TestEnqueueDequeueOrderAndDedup(t *testing.T) {
+	q, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer q.Close()
+
+	urls := []string{"https://example.com/a", "https://example.com/b"}
+	for _, u := range urls {
+		if err := q.Enqueue(u); err != nil {
+			t.Fatalf("Enqueue(%s): %v", u, err)
+		}
+	}
+	// Re-enqueueing an already-seen URL must be a no-op.
+	if err := q.Enqueue(urls[0]); err != nil {
+		t.Fatalf("Enqueue(dup): %v", err)
+	}
+	if got, want := q.Len(), len(urls); got != want {
+		t.Fatalf("Len() = %d, want %d", got, want)
+	}
+
+	for _, want := range urls {
+		got, ok, err := q.Dequeue()
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		if !ok {
+			t.Fatalf("Dequeue: ok = false, want true")
+		}
+		if got != want {
+			t.Fatalf("Dequeue() = %q, want %q", got, want)
+		}
+		if err := q.Ack(got); err != nil {
+			t.Fatalf("Ack(%s): %v", got, err)
+		}
+	}
+
+	if _, ok, err := q.Dequeue(); err != nil || ok {
+		t.Fatalf("Dequeue on empty queue = (_, %v, %v), want (_, false, nil)", ok, err)
+	}
+}
+
+func TestRequeueAfterFailureIsRetried(t *testing.T) {
+	q, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer q.Close()
+
+	if err := q.Enqueue("https://example.com/flaky"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	url, ok, err := q.Dequeue()
+	if err != nil || !ok {
+		t.Fatalf("Dequeue = (%q, %v, %v)", url, ok, err)
+	}
+	if err := q.Requeue(url); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+
+	got, ok, err := q.Dequeue()
+	if err != nil || !ok || got != url {
+		t.Fatalf("Dequeue after Requeue = (%q, %v, %v), want (%q, true, nil)", got, ok, err, url)
+	}
+}
+
+// TestUnackedLeaseSurvivesRestart reproduces a worker crashing after
+// Dequeue but before Ack (or Requeue): the URL must not be lost, so the
+// next Open on the same directory should put it back on the queue.
+func TestUnackedLeaseSurvivesRestart(t *testing.T) {
+	directory := t.TempDir()
+
+	q, err := Open(directory)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := q.Enqueue("https://example.com/in-flight"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	url, ok, err := q.Dequeue()
+	if err != nil || !ok {
+		t.Fatalf("Dequeue = (%q, %v, %v)", url, ok, err)
+	}
+	// Simulate a crash: neither Ack nor Requeue is called, and the
+	// process-local Queue is abandoned without closing it cleanly.
+
+	reopened, err := Open(directory)
+	if err != nil {
+		t.Fatalf("re-Open: %v", err)
+	}
+	defer reopened.Close()
+
+	if got, want := reopened.Len(), 1; got != want {
+		t.Fatalf("Len() after restart = %d, want %d (lease should have been re-enqueued)", got, want)
+	}
+	got, ok, err := reopened.Dequeue()
+	if err != nil || !ok || got != url {
+		t.Fatalf("Dequeue after restart = (%q, %v, %v), want (%q, true, nil)", got, ok, err, url)
+	}
+}
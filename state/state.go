@@ -0,0 +1,139 @@
+// Package state tracks per-URL crawl progress on disk so a long-running
+// Bio-Rad harvest can be interrupted and resumed without re-fetching work
+// that already completed.
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Entry records what is known about a single previously fetched URL.
+type Entry struct {
+	Status         string `json:"status"` // "pending", "done", or "error"
+	ETag           string `json:"etag,omitempty"`
+	LastModified   string `json:"last_modified,omitempty"`
+	ContentSHA256  string `json:"content_sha256,omitempty"`
+	CompletedAtUTC string `json:"completed_at_utc,omitempty"`
+	// RequeueAttempts counts how many times this URL has been sent back to
+	// the visit queue after a failed download, so a caller can give up and
+	// dead-letter it instead of retrying forever.
+	RequeueAttempts int `json:"requeue_attempts,omitempty"`
+}
+
+// record is a single update in the on-disk write-ahead log: it pairs an
+// Entry with the URL it belongs to, since Entry itself doesn't carry its
+// key.
+type record struct {
+	URL   string `json:"url"`
+	Entry Entry  `json:"entry"`
+}
+
+// Store is a write-ahead-log-backed key/value store keyed by URL. Every
+// mutation is appended to filePath and fsynced immediately, so a crash
+// leaves the on-disk log consistent with the most recently recorded
+// entry; unlike rewriting the whole store on every write, this is
+// O(1) per mutation regardless of how many URLs are already tracked.
+// Open replays the log once to rebuild the in-memory map.
+type Store struct {
+	mu       sync.Mutex
+	filePath string
+	entries  map[string]Entry
+}
+
+// Open loads the state file at filePath, creating an empty store if it
+// does not yet exist. Pass a file inside the directory given via --resume.
+func Open(filePath string) (*Store, error) {
+	store := &Store{filePath: filePath, entries: make(map[string]Entry)}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to open state file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("failed to parse state file %s: %w", filePath, err)
+		}
+		store.entries[rec.URL] = rec.Entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", filePath, err)
+	}
+	return store, nil
+}
+
+// Get returns the recorded entry for url, if any.
+func (s *Store) Get(url string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[url]
+	return entry, ok
+}
+
+// IsDone reports whether url was previously completed successfully.
+func (s *Store) IsDone(url string) bool {
+	entry, ok := s.Get(url)
+	return ok && entry.Status == "done"
+}
+
+// Set records entry for url, appending it to the write-ahead log and
+// fsyncing before returning.
+func (s *Store) Set(url string, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[url] = entry
+	return s.appendLocked(url, entry)
+}
+
+// IncrementRequeueAttempts records another failed, requeued attempt at url
+// and persists the store immediately, just like Set. It returns the
+// resulting attempt count so the caller can decide whether to give up.
+func (s *Store) IncrementRequeueAttempts(url string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.entries[url]
+	entry.Status = "error"
+	entry.RequeueAttempts++
+	s.entries[url] = entry
+	if err := s.appendLocked(url, entry); err != nil {
+		return entry.RequeueAttempts, err
+	}
+	return entry.RequeueAttempts, nil
+}
+
+// appendLocked appends a record for url/entry to the write-ahead log and
+// fsyncs it. The caller must hold s.mu.
+func (s *Store) appendLocked(url string, entry Entry) error {
+	data, err := json.Marshal(record{URL: url, Entry: entry})
+	if err != nil {
+		return fmt.Errorf("failed to marshal state entry for %s: %w", url, err)
+	}
+
+	file, err := os.OpenFile(s.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open state file %s: %w", s.filePath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append to state file %s: %w", s.filePath, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to fsync state file %s: %w", s.filePath, err)
+	}
+	return nil
+}
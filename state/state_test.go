@@ -0,0 +1,73 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSetAndGetRoundTrip(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "crawl-state.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	entry := Entry{Status: "done", ContentSHA256: "abc123"}
+	if err := store.Set("https://example.com/a", entry); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got, ok := store.Get("https://example.com/a")
+	if !ok || got != entry {
+		t.Fatalf("Get = (%+v, %v), want (%+v, true)", got, ok, entry)
+	}
+	if !store.IsDone("https://example.com/a") {
+		t.Fatalf("IsDone = false, want true")
+	}
+}
+
+func TestOpenReplaysWriteAheadLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl-state.json")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := store.Set("https://example.com/a", Entry{Status: "done"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	// A later Set for the same URL must win on replay.
+	if err := store.Set("https://example.com/a", Entry{Status: "error"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("re-Open: %v", err)
+	}
+	got, ok := reopened.Get("https://example.com/a")
+	if !ok || got.Status != "error" {
+		t.Fatalf("Get after replay = (%+v, %v), want status=error", got, ok)
+	}
+}
+
+func TestIncrementRequeueAttempts(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "crawl-state.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	for i, want := range []int{1, 2, 3} {
+		got, err := store.IncrementRequeueAttempts("https://example.com/flaky")
+		if err != nil {
+			t.Fatalf("IncrementRequeueAttempts (call %d): %v", i, err)
+		}
+		if got != want {
+			t.Fatalf("IncrementRequeueAttempts (call %d) = %d, want %d", i, got, want)
+		}
+	}
+
+	entry, ok := store.Get("https://example.com/flaky")
+	if !ok || entry.RequeueAttempts != 3 || entry.Status != "error" {
+		t.Fatalf("Get = (%+v, %v), want RequeueAttempts=3, Status=error", entry, ok)
+	}
+}
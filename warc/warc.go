@@ -0,0 +1,92 @@
+// Package warc implements a minimal WARC (Web ARChive, ISO 28500) writer
+// sufficient for archiving the pages and documents this scraper downloads.
+// Each record is gzipped independently (rather than the file as a whole)
+// so that a truncated or interrupted write still leaves every prior record
+// readable by standard WARC tooling.
+package warc
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Writer appends gzip-per-record WARC entries to a single output file.
+// It is safe for concurrent use by multiple goroutines.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewWriter opens (creating if necessary) the WARC file at filePath for
+// appending. Existing content is preserved so a long-running harvest can be
+// resumed without losing previously archived records.
+func NewWriter(filePath string) (*Writer, error) {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WARC file %s: %w", filePath, err)
+	}
+	return &Writer{file: file}, nil
+}
+
+// Close flushes and closes the underlying WARC file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// newRecordID generates a warcinfo-style urn:uuid record identifier.
+func newRecordID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	buf[6] = (buf[6] & 0x0f) | 0x40 // version 4
+	buf[8] = (buf[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:16])
+}
+
+// WriteResponse appends a single WARC `response` record for targetURI
+// containing body as its HTTP-equivalent payload, tagged with contentType.
+// The record is gzip-compressed on its own so the file stays valid WARC
+// even if the process is killed mid-write.
+func (w *Writer) WriteResponse(targetURI string, contentType string, body []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	warcDate := time.Now().UTC().Format(time.RFC3339)
+	recordID := newRecordID()
+
+	// A WARC response record carries a synthetic HTTP response so the
+	// payload can be replayed by standard WARC tooling (e.g. pywb).
+	httpHeader := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n", contentType, len(body))
+	payload := append([]byte(httpHeader), body...)
+
+	header := fmt.Sprintf(
+		"WARC/1.1\r\n"+
+			"WARC-Type: response\r\n"+
+			"WARC-Record-ID: %s\r\n"+
+			"WARC-Date: %s\r\n"+
+			"WARC-Target-URI: %s\r\n"+
+			"Content-Type: application/http; msgtype=response\r\n"+
+			"Content-Length: %d\r\n\r\n",
+		recordID, warcDate, targetURI, len(payload))
+
+	gz := gzip.NewWriter(w.file)
+	if _, err := io.WriteString(gz, header); err != nil {
+		return fmt.Errorf("failed to write WARC header for %s: %w", targetURI, err)
+	}
+	if _, err := gz.Write(payload); err != nil {
+		return fmt.Errorf("failed to write WARC payload for %s: %w", targetURI, err)
+	}
+	if _, err := io.WriteString(gz, "\r\n\r\n"); err != nil {
+		return fmt.Errorf("failed to write WARC record trailer for %s: %w", targetURI, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to close WARC gzip member for %s: %w", targetURI, err)
+	}
+	return nil
+}